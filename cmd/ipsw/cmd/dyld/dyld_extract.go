@@ -22,16 +22,18 @@ THE SOFTWARE.
 package dyld
 
 import (
-	"encoding/binary"
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime"
+	"sync"
 
 	"github.com/apex/log"
-	"github.com/blacktop/go-macho"
-	"github.com/blacktop/go-macho/pkg/fixupchains"
 	"github.com/blacktop/ipsw/pkg/dyld"
+	dyldextract "github.com/blacktop/ipsw/pkg/dyld/extract"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -39,66 +41,339 @@ import (
 	"github.com/vbauerster/mpb/v7/decor"
 )
 
-func rebaseMachO(dsc *dyld.File, machoPath string) error {
-	f, err := os.OpenFile(machoPath, os.O_RDWR, 0755)
+func init() {
+	DyldCmd.AddCommand(dyldExtractCmd)
+	dyldExtractCmd.Flags().BoolP("all", "a", false, "Split ALL dylibs")
+	dyldExtractCmd.Flags().Bool("force", false, "Overwrite existing extracted dylib(s)")
+	dyldExtractCmd.Flags().Bool("slide", false, "Apply slide info to extracted dylib(s)")
+	dyldExtractCmd.Flags().StringP("output", "o", "", "Directory to extract the dylib(s) (with --archive: the archive file to write)")
+	dyldExtractCmd.Flags().IntP("jobs", "j", runtime.NumCPU(), "Number of concurrent extraction workers to use with --all")
+	dyldExtractCmd.Flags().String("upload-url", "", "Symbol server base URL to upload extracted dylib(s) to")
+	dyldExtractCmd.Flags().String("bearer-token", "", "Bearer token to authenticate to --upload-url")
+	dyldExtractCmd.Flags().Bool("only-missing", false, "Only upload dylib(s) the symbol server doesn't already have")
+	dyldExtractCmd.Flags().String("cache-dir", "", "Extraction cache directory (default $HOME/.cache/ipsw/extracted)")
+	dyldExtractCmd.Flags().Bool("no-cache", false, "Don't use the extraction cache")
+	dyldExtractCmd.Flags().String("archive", "", "Archive extracted dylib(s) instead of writing them out individually (zstd-chunked, tar)")
+	dyldExtractCmd.Flags().Bool("stdout", false, "Write the --archive to stdout instead of a file (e.g. for piping into tar -x)")
+	viper.BindPFlag("dyld.extract.all", dyldExtractCmd.Flags().Lookup("all"))
+	viper.BindPFlag("dyld.extract.force", dyldExtractCmd.Flags().Lookup("force"))
+	viper.BindPFlag("dyld.extract.slide", dyldExtractCmd.Flags().Lookup("slide"))
+	viper.BindPFlag("dyld.extract.output", dyldExtractCmd.Flags().Lookup("output"))
+	viper.BindPFlag("dyld.extract.jobs", dyldExtractCmd.Flags().Lookup("jobs"))
+	viper.BindPFlag("dyld.extract.upload-url", dyldExtractCmd.Flags().Lookup("upload-url"))
+	viper.BindPFlag("dyld.extract.bearer-token", dyldExtractCmd.Flags().Lookup("bearer-token"))
+	viper.BindPFlag("dyld.extract.only-missing", dyldExtractCmd.Flags().Lookup("only-missing"))
+	viper.BindPFlag("dyld.extract.cache-dir", dyldExtractCmd.Flags().Lookup("cache-dir"))
+	viper.BindPFlag("dyld.extract.no-cache", dyldExtractCmd.Flags().Lookup("no-cache"))
+	viper.BindPFlag("dyld.extract.archive", dyldExtractCmd.Flags().Lookup("archive"))
+	viper.BindPFlag("dyld.extract.stdout", dyldExtractCmd.Flags().Lookup("stdout"))
+}
+
+// extractConfig bundles the pieces every extracted image needs that are
+// shared across the whole `--all` run (or the single-dylib path), so they
+// don't have to be threaded through extractOne/extractAll as a long parameter
+// list.
+type extractConfig struct {
+	forceExtract bool
+	slide        bool
+	dscUUID      string
+	ipswVersion  string
+	cache        *dyldextract.Cache // nil when caching is disabled
+	uploader     *dyldextract.Uploader
+}
+
+// extractOne exports a single image via ex, serving it out of cfg.cache when
+// possible and populating the cache on a miss, then uploads it (and its
+// companion .dSYM, if present next to the extracted dylib) via cfg.uploader
+// when set. It
+// returns the path extracted to (or "" if it was skipped because it already
+// existed).
+func extractOne(ctx context.Context, ex *dyldextract.Extractor, cfg *extractConfig, image *dyld.CacheImage, fname string) (string, error) {
+	if _, err := os.Stat(fname); !os.IsNotExist(err) && !cfg.forceExtract {
+		return "", nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fname), 0o750); err != nil {
+		return "", fmt.Errorf("failed to create output directory for %s: %v", fname, err)
+	}
+
+	// open the Mach-O once and reuse it for both the cache-key UUID and (on a
+	// miss) the actual export, instead of parsing it twice per image.
+	m, err := image.GetMacho()
 	if err != nil {
-		return fmt.Errorf("failed to open exported MachO %s: %v", machoPath, err)
+		return "", err
 	}
-	defer f.Close()
+	defer m.Close()
+	imgUUID := m.UUID().String()
+
+	var key string
+	if cfg.cache != nil {
+		key = dyldextract.Key(cfg.dscUUID, imgUUID, cfg.slide, cfg.ipswVersion)
+		if sum, ok, err := cfg.cache.Lookup(key); err == nil && ok {
+			if err := cfg.cache.Link(sum, fname); err == nil {
+				log.Debugf("Cache hit for %s (%s)", image.Name, sum)
+				return maybeUpload(ctx, cfg, imgUUID, fname)
+			}
+		}
+	}
+
+	// fname may still be a hardlink into the CAS from an earlier cache hit;
+	// unlink it so the export below writes a new file instead of the blob.
+	os.Remove(fname)
 
-	mm, err := macho.NewFile(f)
+	if _, err := ex.ExtractMacho(m, image, fname); err != nil {
+		return "", err
+	}
+
+	if key != "" {
+		if _, err := cfg.cache.Store(key, fname); err != nil {
+			log.Debugf("failed to cache %s: %v", image.Name, err)
+		}
+	}
+
+	return maybeUpload(ctx, cfg, imgUUID, fname)
+}
+
+func maybeUpload(ctx context.Context, cfg *extractConfig, imgUUID, fname string) (string, error) {
+	if cfg.uploader != nil {
+		dsym := fname + ".dSYM"
+		if _, err := os.Stat(dsym); os.IsNotExist(err) {
+			dsym = ""
+		}
+		if err := cfg.uploader.Upload(ctx, imgUUID, fname, dsym); err != nil {
+			return "", fmt.Errorf("failed to upload %s: %v", fname, err)
+		}
+	}
+	return fname, nil
+}
+
+// extractAllToArchive fans extraction of images out across jobs workers (same
+// as extractAll) but, instead of writing each dylib out individually, stages
+// every export into a temp file and appends it as an entry in aw. Staging
+// through a temp file (rather than buffering in memory) keeps at most `jobs`
+// dylibs of extra disk usage alive at once, regardless of how many images are
+// being archived, and sidesteps rebaseMachO needing to os.OpenFile+seek a
+// real file on disk.
+func extractAllToArchive(ctx context.Context, dscPath string, aw dyldextract.ArchiveEntryWriter, images []*dyld.CacheImage, jobs int, cfg *extractConfig, bar *mpb.Bar) error {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	workers, err := openWorkers(dscPath, jobs, cfg.slide)
 	if err != nil {
 		return err
 	}
+	defer workers.Close()
 
-	for _, seg := range mm.Segments() {
-		uuid, mapping, err := dsc.GetMappingForVMAddress(seg.Addr)
-		if err != nil {
-			return err
-		}
+	var awMu sync.Mutex // ArchiveEntryWriter isn't safe for concurrent AddFile calls
 
-		if mapping.SlideInfoOffset == 0 {
-			continue
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	imageCh := make(chan *dyld.CacheImage)
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	for _, w := range workers.pool {
+		w := w
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for image := range imageCh {
+				select {
+				case <-ctx.Done():
+					continue
+				default:
+				}
+				// re-resolve image against this worker's own *dyld.File: the
+				// one fed in over imageCh came from the caller's shared handle,
+				// and GetMacho/ParseLocalSymbols read/mutate through whichever
+				// *dyld.File they're called on, so crossing goroutines would
+				// mean every worker hammering the one shared handle anyway.
+				workerImage, err := w.dsc.Image(image.Name)
+				if err != nil {
+					fail(fmt.Errorf("%s: %v", image.Name, err))
+					continue
+				}
+				if err := archiveOne(w.ex, aw, &awMu, workerImage); err != nil {
+					fail(fmt.Errorf("%s: %v", image.Name, err))
+					continue
+				}
+				bar.Increment()
+			}
+		}()
+	}
+
+	for _, image := range images {
+		select {
+		case <-ctx.Done():
+		case imageCh <- image:
 		}
+	}
+	close(imageCh)
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 
-		startAddr := seg.Addr - mapping.Address
-		endAddr := ((seg.Addr + seg.Memsz) - mapping.Address) + uint64(dsc.SlideInfo.GetPageSize())
+	return aw.Close()
+}
+
+// archiveOne extracts image into a temp file via ex and appends it to aw,
+// guarding aw with mu since an ArchiveEntryWriter writes sequentially into
+// one underlying stream.
+func archiveOne(ex *dyldextract.Extractor, aw dyldextract.ArchiveEntryWriter, mu *sync.Mutex, image *dyld.CacheImage) error {
+	tmp, err := os.CreateTemp("", "ipsw-extract-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %v", err)
+	}
+	tmpPath := tmp.Name()
+	tmp.Close()
+	defer os.Remove(tmpPath)
 
-		start := startAddr / uint64(dsc.SlideInfo.GetPageSize())
-		end := endAddr / uint64(dsc.SlideInfo.GetPageSize())
+	if _, err := ex.Extract(image, tmpPath); err != nil {
+		return err
+	}
 
-		rebases, err := dsc.GetRebaseInfoForPages(uuid, mapping, start, end)
+	f, err := os.Open(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to reopen extracted %s: %v", image.Name, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	return aw.AddFile(image.Name, f, info.Size())
+}
+
+// worker pairs a *dyld.File opened for a single extraction worker with the
+// Extractor wrapping it.
+type worker struct {
+	dsc *dyld.File
+	ex  *dyldextract.Extractor
+}
+
+// workerPool is jobs independently-opened *dyld.File handles on the same DSC,
+// one per extraction worker goroutine, so concurrent GetMacho/ParseLocalSymbols/
+// rebase calls never cross the same *dyld.File.
+type workerPool struct {
+	pool []worker
+}
+
+// openWorkers opens jobs independent *dyld.File handles on dscPath. If a
+// later open fails, every handle opened so far is closed before returning the
+// error, so callers never have to worry about leaking partially-opened pools.
+func openWorkers(dscPath string, jobs int, slide bool) (*workerPool, error) {
+	wp := &workerPool{pool: make([]worker, 0, jobs)}
+	for i := 0; i < jobs; i++ {
+		dsc, err := dyld.Open(dscPath)
 		if err != nil {
-			return err
+			wp.Close()
+			return nil, fmt.Errorf("failed to open %s for worker: %v", dscPath, err)
 		}
+		wp.pool = append(wp.pool, worker{dsc: dsc, ex: dyldextract.NewExtractor(dsc, slide)})
+	}
+	return wp, nil
+}
 
-		for _, rebase := range rebases {
-			off, err := mm.GetOffset(rebase.CacheVMAddress)
-			if err != nil {
-				continue
-			}
-			if _, err := f.Seek(int64(off), io.SeekStart); err != nil {
-				return fmt.Errorf("failed to seek in exported file to offset %#x from the start: %v", off, err)
-			}
-			if err := binary.Write(f, dsc.ByteOrder, rebase.Target); err != nil {
-				return fmt.Errorf("failed to write rebase address %#x: %v", rebase.Target, err)
+// Close closes every *dyld.File opened for the pool.
+func (wp *workerPool) Close() {
+	for _, w := range wp.pool {
+		w.dsc.Close()
+	}
+}
+
+func extractAll(ctx context.Context, dscPath, folder string, images []*dyld.CacheImage, jobs int, cfg *extractConfig, bar *mpb.Bar) error {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	// each worker gets its own *dyld.File (and re-resolves every image
+	// against it) since the underlying reader isn't safe to share across
+	// goroutines - see openWorkers.
+	workers, err := openWorkers(dscPath, jobs, cfg.slide)
+	if err != nil {
+		return err
+	}
+	defer workers.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	imageCh := make(chan *dyld.CacheImage)
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	for _, w := range workers.pool {
+		w := w
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for image := range imageCh {
+				select {
+				case <-ctx.Done():
+					continue // drain remaining images without doing work
+				default:
+				}
+				workerImage, err := w.dsc.Image(image.Name)
+				if err != nil {
+					fail(fmt.Errorf("%s: %v", image.Name, err))
+					continue
+				}
+				fname := filepath.Join(folder, image.Name)
+				out, err := extractOne(ctx, w.ex, cfg, workerImage, fname)
+				if err != nil {
+					fail(fmt.Errorf("%s: %v", image.Name, err))
+					continue
+				}
+				if out == "" {
+					log.Warnf("Dylib already exists: %s", fname)
+				}
+				bar.Increment()
 			}
+		}()
+	}
+
+	for _, image := range images {
+		select {
+		case <-ctx.Done():
+		case imageCh <- image:
 		}
 	}
+	close(imageCh)
 
-	return nil
-}
+	wg.Wait()
 
-func init() {
-	DyldCmd.AddCommand(dyldExtractCmd)
-	dyldExtractCmd.Flags().BoolP("all", "a", false, "Split ALL dylibs")
-	dyldExtractCmd.Flags().Bool("force", false, "Overwrite existing extracted dylib(s)")
-	dyldExtractCmd.Flags().Bool("slide", false, "Apply slide info to extracted dylib(s)")
-	dyldExtractCmd.Flags().StringP("output", "o", "", "Directory to extract the dylib(s)")
-	viper.BindPFlag("dyld.extract.all", dyldExtractCmd.Flags().Lookup("all"))
-	viper.BindPFlag("dyld.extract.force", dyldExtractCmd.Flags().Lookup("force"))
-	viper.BindPFlag("dyld.extract.slide", dyldExtractCmd.Flags().Lookup("slide"))
-	viper.BindPFlag("dyld.extract.output", dyldExtractCmd.Flags().Lookup("output"))
+	if ctx.Err() != nil && firstErr == nil {
+		return ctx.Err()
+	}
+	return firstErr
 }
 
 // dyldExtractCmd represents the extractDyld command
@@ -118,17 +393,84 @@ var dyldExtractCmd = &cobra.Command{
 			log.SetLevel(log.DebugLevel)
 		}
 
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
 		// flags
 		dumpALL := viper.GetBool("dyld.extract.all")
 		forceExtract := viper.GetBool("dyld.extract.force")
 		slide := viper.GetBool("dyld.extract.slide")
 		output := viper.GetString("dyld.extract.output")
+		jobs := viper.GetInt("dyld.extract.jobs")
+		uploadURL := viper.GetString("dyld.extract.upload-url")
+		bearerToken := viper.GetString("dyld.extract.bearer-token")
+		onlyMissing := viper.GetBool("dyld.extract.only-missing")
+		cacheDir := viper.GetString("dyld.extract.cache-dir")
+		noCache := viper.GetBool("dyld.extract.no-cache")
+		archive := viper.GetString("dyld.extract.archive")
+		toStdout := viper.GetBool("dyld.extract.stdout")
 		// validate flags
+		if dumpALL && len(args) == 2 && args[1] == "-" {
+			// `--all DSC -` is shorthand for `--all --archive tar --stdout DSC`,
+			// so the documented `ipsw dyld extract --all DSC - | tar -x`
+			// pipeline works without spelling out --archive/--stdout too.
+			toStdout = true
+			if archive == "" {
+				archive = "tar"
+			}
+			args = args[:1]
+		}
 		if dumpALL && len(args) > 1 {
 			return fmt.Errorf("cannot specify DYLIB(s) when using --all")
 		} else if !dumpALL && len(args) < 2 {
 			return fmt.Errorf("must specify at least one DYLIB to extract")
 		}
+		if onlyMissing && uploadURL == "" {
+			return fmt.Errorf("--only-missing requires --upload-url")
+		}
+		switch archive {
+		case "", "zstd-chunked", "tar":
+		default:
+			return fmt.Errorf("invalid --archive %q: must be one of: zstd-chunked, tar", archive)
+		}
+		if archive != "" && !dumpALL {
+			return fmt.Errorf("--archive requires --all")
+		}
+		if archive != "" && uploadURL != "" {
+			return fmt.Errorf("--archive does not support --upload-url yet")
+		}
+		if archive != "" {
+			// the archive path doesn't go through extractOne's per-image cache
+			// lookup, so there's nothing for the cache to do here - rather than
+			// making --cache-dir's default-on behavior an error for every
+			// --archive run, just don't bother opening it.
+			noCache = true
+		}
+		if toStdout && archive == "" {
+			return fmt.Errorf("--stdout requires --archive")
+		}
+
+		var uploader *dyldextract.Uploader
+		if uploadURL != "" {
+			uploader = dyldextract.NewUploader(dyldextract.NewHTTPSink(uploadURL, bearerToken), onlyMissing)
+		}
+
+		var cache *dyldextract.Cache
+		if !noCache {
+			if cacheDir == "" {
+				var err error
+				cacheDir, err = dyldextract.DefaultCacheDir()
+				if err != nil {
+					return fmt.Errorf("failed to determine default cache dir: %v", err)
+				}
+			}
+			var err error
+			cache, err = dyldextract.OpenCache(cacheDir)
+			if err != nil {
+				return fmt.Errorf("failed to open extraction cache: %v", err)
+			}
+			defer cache.Close()
+		}
 
 		dscPath := filepath.Clean(args[0])
 
@@ -161,11 +503,27 @@ var dyldExtractCmd = &cobra.Command{
 		}
 		defer f.Close()
 
+		finder := dyldextract.NewFinder(f)
+
+		cfg := &extractConfig{
+			forceExtract: forceExtract,
+			slide:        slide,
+			dscUUID:      f.UUID.String(),
+			ipswVersion:  cmd.Root().Version,
+			cache:        cache,
+			uploader:     uploader,
+		}
+
 		if dumpALL {
 			// set images to all images in shared cache
 			images = f.Images
-			// initialize progress bar
-			p = mpb.New(mpb.WithWidth(80))
+			// initialize progress bar (stderr when the archive itself is going
+			// out on stdout, so the two streams don't interleave)
+			mpbOpts := []mpb.ContainerOption{mpb.WithWidth(80)}
+			if toStdout {
+				mpbOpts = append(mpbOpts, mpb.WithOutput(os.Stderr))
+			}
+			p = mpb.New(mpbOpts...)
 			// adding a single bar, which will inherit container's width
 			name := "      "
 			bar = p.New(int64(len(images)),
@@ -185,66 +543,88 @@ var dyldExtractCmd = &cobra.Command{
 			)
 			log.Infof("Extracting all dylibs from %s", dscPath)
 		} else {
-			// get images from args
+			// get images from args (install name, glob, regex or LC_UUID)
 			images = make([]*dyld.CacheImage, 0, len(args)-1)
 			for _, arg := range args[1:] {
-				image, err := f.Image(arg)
+				matches, err := finder.Find(arg)
 				if err != nil {
-					return err
+					if image, uerr := finder.FindByUUID(arg); uerr == nil {
+						matches = []*dyld.CacheImage{image}
+					} else {
+						return err
+					}
 				}
-				images = append(images, image)
+				images = append(images, matches...)
 			}
 		}
 
-		for _, image := range images {
-			m, err := image.GetMacho()
-			if err != nil {
-				return err
-			}
-
-			fname := filepath.Join(folder, filepath.Base(image.Name)) // default to NOT full dylib path
-			if dumpALL {
-				fname = filepath.Join(folder, image.Name)
-			}
-
-			if _, err := os.Stat(fname); os.IsNotExist(err) || forceExtract {
-				var dcf *fixupchains.DyldChainedFixups
-				if m.HasFixups() {
-					dcf, err = m.DyldChainedFixups()
-					if err != nil {
-						return fmt.Errorf("failed to parse fixups from in memory MachO: %v", err)
-					}
-				}
-
-				image.ParseLocalSymbols(false)
-
-				if err := m.Export(fname, dcf, m.GetBaseAddress(), image.GetLocalSymbolsAsMachoSymbols()); err != nil {
-					return fmt.Errorf("failed to extract dylib %s: %v", image.Name, err)
+		if dumpALL && archive != "" {
+			var w io.Writer
+			var archivePath string
+			if toStdout {
+				w = os.Stdout
+			} else {
+				archivePath = output
+				if archivePath == "" {
+					// zstd-chunked is its own format (concatenated independent
+					// zstd frames + JSON TOC + skippable trailer) and is not a
+					// valid tar or a single-stream-decompressible zstd file,
+					// so it gets its own extension rather than claiming
+					// .tar.zst compatibility it doesn't have.
+					ext := map[string]string{"zstd-chunked": ".ipswz", "tar": ".tar"}[archive]
+					archivePath = dscPath + ext
 				}
-				if slide {
-					if err := rebaseMachO(f, fname); err != nil {
-						return fmt.Errorf("failed to rebase dylib via cache slide info: %v", err)
-					}
+				out, err := os.Create(archivePath)
+				if err != nil {
+					return fmt.Errorf("failed to create archive %s: %v", archivePath, err)
 				}
+				defer out.Close()
+				w = out
+			}
 
-				if dumpALL {
-					bar.Increment()
-				} else {
-					log.Infof("Created %s", fname)
-				}
+			var aw dyldextract.ArchiveEntryWriter
+			if archive == "tar" {
+				aw = dyldextract.NewTarWriter(w)
 			} else {
-				if dumpALL {
-					bar.Increment()
-				} else {
-					log.Warnf("Dylib already exists: %s", fname)
-				}
+				aw = dyldextract.NewArchiveWriter(w)
 			}
 
-			m.Close()
+			if err := extractAllToArchive(ctx, dscPath, aw, images, jobs, cfg, bar); err != nil {
+				return err
+			}
+			p.Wait()
+			if archivePath != "" {
+				log.Infof("Created %s", archivePath)
+			}
+			return nil
 		}
 
 		if dumpALL {
+			if err := extractAll(ctx, dscPath, folder, images, jobs, cfg, bar); err != nil {
+				return err
+			}
 			p.Wait()
+			return nil
+		}
+
+		extractor := dyldextract.NewExtractor(f, slide)
+
+		for _, image := range images {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+			fname := filepath.Join(folder, filepath.Base(image.Name)) // default to NOT full dylib path
+			out, err := extractOne(ctx, extractor, cfg, image, fname)
+			if err != nil {
+				return err
+			}
+			if out == "" {
+				log.Warnf("Dylib already exists: %s", fname)
+			} else {
+				log.Infof("Created %s", out)
+			}
 		}
 
 		return nil
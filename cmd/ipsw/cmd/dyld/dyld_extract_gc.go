@@ -0,0 +1,75 @@
+/*
+Copyright © 2018-2023 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package dyld
+
+import (
+	"fmt"
+
+	"github.com/apex/log"
+	dyldextract "github.com/blacktop/ipsw/pkg/dyld/extract"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	dyldExtractCmd.AddCommand(dyldExtractGcCmd)
+	dyldExtractGcCmd.Flags().String("cache-dir", "", "Extraction cache directory (default $HOME/.cache/ipsw/extracted)")
+	viper.BindPFlag("dyld.extract.gc.cache-dir", dyldExtractGcCmd.Flags().Lookup("cache-dir"))
+}
+
+// dyldExtractGcCmd represents the `dyld extract gc` command
+var dyldExtractGcCmd = &cobra.Command{
+	Use:           "gc",
+	Short:         "Remove orphaned blobs from the extraction cache",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		cacheDir := viper.GetString("dyld.extract.gc.cache-dir")
+		if cacheDir == "" {
+			var err error
+			cacheDir, err = dyldextract.DefaultCacheDir()
+			if err != nil {
+				return fmt.Errorf("failed to determine default cache dir: %v", err)
+			}
+		}
+
+		cache, err := dyldextract.OpenCache(cacheDir)
+		if err != nil {
+			return fmt.Errorf("failed to open extraction cache: %v", err)
+		}
+		defer cache.Close()
+
+		removed, err := cache.GC()
+		if err != nil {
+			return fmt.Errorf("failed to gc extraction cache: %v", err)
+		}
+
+		log.Infof("Removed %d orphaned blob(s) from %s", removed, cacheDir)
+
+		return nil
+	},
+}
@@ -0,0 +1,94 @@
+/*
+Copyright © 2018-2023 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package dyld
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/apex/log"
+	dyldextract "github.com/blacktop/ipsw/pkg/dyld/extract"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	dyldExtractCmd.AddCommand(dyldExtractGetCmd)
+	dyldExtractGetCmd.Flags().StringP("output", "o", "", "Directory to write the dylib to")
+	viper.BindPFlag("dyld.extract.get.output", dyldExtractGetCmd.Flags().Lookup("output"))
+}
+
+// dyldExtractGetCmd represents the `dyld extract get` command
+var dyldExtractGetCmd = &cobra.Command{
+	Use:           "get <ARCHIVE> <DYLIB>",
+	Short:         "Read a single dylib out of a zstd-chunked extraction archive",
+	Args:          cobra.ExactArgs(2),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if viper.GetBool("verbose") {
+			log.SetLevel(log.DebugLevel)
+		}
+
+		output := viper.GetString("dyld.extract.get.output")
+
+		archivePath := filepath.Clean(args[0])
+		dylibPath := args[1]
+
+		archive, err := dyldextract.OpenArchive(archivePath)
+		if err != nil {
+			return fmt.Errorf("failed to open archive %s: %v", archivePath, err)
+		}
+		defer archive.Close()
+
+		r, err := archive.Get(dylibPath)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		folder := "."
+		if output != "" {
+			folder = output
+		}
+		fname := filepath.Join(folder, filepath.Base(dylibPath))
+		if err := os.MkdirAll(filepath.Dir(fname), 0o750); err != nil {
+			return fmt.Errorf("failed to create output directory for %s: %v", fname, err)
+		}
+
+		out, err := os.Create(fname)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %v", fname, err)
+		}
+		defer out.Close()
+
+		if _, err := io.Copy(out, r); err != nil {
+			return fmt.Errorf("failed to write %s: %v", fname, err)
+		}
+
+		log.Infof("Created %s", fname)
+
+		return nil
+	},
+}
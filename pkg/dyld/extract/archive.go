@@ -0,0 +1,239 @@
+/*
+Copyright © 2018-2023 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package extract
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/edsrzf/mmap-go"
+	"github.com/klauspost/compress/zstd"
+)
+
+// zstd-chunked layout: every entry is its own independent zstd frame (so a
+// reader never has to decompress more than the one entry it wants), followed
+// by one more zstd frame holding the JSON TOC, followed by a zstd skippable
+// frame (magic 0x184D2A50, a type generic zstd decoders are required to skip)
+// whose content is just the TOC frame's [offset, length] - giving Archive a
+// fixed-size trailer to read to find the TOC without scanning the file.
+const (
+	skippableFrameMagic = 0x184D2A50
+	trailerContentSize  = 16 // tocOffset(8) + tocLength(8)
+	trailerSize         = 8 + trailerContentSize
+)
+
+// TOCEntry describes one file stored in a zstd-chunked archive.
+type TOCEntry struct {
+	Path             string `json:"path"`
+	UncompressedSize int64  `json:"uncompressed_size"`
+	Offset           int64  `json:"offset"` // frame start, from beginning of archive
+	Length           int64  `json:"length"` // compressed frame length
+	SHA256           string `json:"sha256"`
+}
+
+// toc is the JSON document stored as the archive's penultimate zstd frame.
+type toc struct {
+	Entries []TOCEntry `json:"entries"`
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// ArchiveWriter streams files into a zstd-chunked archive: each AddFile call
+// compresses its input as its own zstd frame so Archive.Get can later
+// decompress a single entry without touching the rest of the file.
+type ArchiveWriter struct {
+	cw  *countingWriter
+	toc toc
+}
+
+// NewArchiveWriter returns an ArchiveWriter that writes to w.
+func NewArchiveWriter(w io.Writer) *ArchiveWriter {
+	return &ArchiveWriter{cw: &countingWriter{w: w}}
+}
+
+// AddFile compresses the contents of r as an independent zstd frame and
+// records it in the archive's TOC under path.
+func (a *ArchiveWriter) AddFile(path string, r io.Reader, uncompressedSize int64) error {
+	start := a.cw.n
+
+	h := sha256.New()
+	zw, err := zstd.NewWriter(a.cw)
+	if err != nil {
+		return fmt.Errorf("failed to start zstd frame for %s: %v", path, err)
+	}
+	if _, err := io.Copy(zw, io.TeeReader(r, h)); err != nil {
+		zw.Close()
+		return fmt.Errorf("failed to compress %s: %v", path, err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to close zstd frame for %s: %v", path, err)
+	}
+
+	a.toc.Entries = append(a.toc.Entries, TOCEntry{
+		Path:             path,
+		UncompressedSize: uncompressedSize,
+		Offset:           start,
+		Length:           a.cw.n - start,
+		SHA256:           hex.EncodeToString(h.Sum(nil)),
+	})
+	return nil
+}
+
+// Close writes the TOC frame and the trailing skippable frame that points to
+// it. The ArchiveWriter must not be used again afterwards.
+func (a *ArchiveWriter) Close() error {
+	tocOffset := a.cw.n
+
+	data, err := json.Marshal(a.toc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal archive TOC: %v", err)
+	}
+
+	zw, err := zstd.NewWriter(a.cw)
+	if err != nil {
+		return fmt.Errorf("failed to start zstd frame for archive TOC: %v", err)
+	}
+	if _, err := zw.Write(data); err != nil {
+		zw.Close()
+		return fmt.Errorf("failed to write archive TOC: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to close archive TOC frame: %v", err)
+	}
+	tocLength := a.cw.n - tocOffset
+
+	trailer := make([]byte, trailerSize)
+	binary.LittleEndian.PutUint32(trailer[0:4], skippableFrameMagic)
+	binary.LittleEndian.PutUint32(trailer[4:8], trailerContentSize)
+	binary.LittleEndian.PutUint64(trailer[8:16], uint64(tocOffset))
+	binary.LittleEndian.PutUint64(trailer[16:24], uint64(tocLength))
+
+	_, err = a.cw.Write(trailer)
+	return err
+}
+
+// Archive is a read-only, mmap-backed view of a zstd-chunked archive that
+// gives O(1) random access to any one entry without decompressing the rest.
+type Archive struct {
+	f   *os.File
+	mm  mmap.MMap
+	toc toc
+}
+
+// OpenArchive mmaps path and reads its trailer + TOC.
+func OpenArchive(path string) (*Archive, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	mm, err := mmap.Map(f, mmap.RDONLY, 0)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to mmap %s: %v", path, err)
+	}
+
+	a := &Archive{f: f, mm: mm}
+	if err := a.readTOC(); err != nil {
+		a.Close()
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *Archive) readTOC() error {
+	if len(a.mm) < trailerSize {
+		return fmt.Errorf("archive too small to contain a trailer")
+	}
+
+	trailer := a.mm[len(a.mm)-trailerSize:]
+	if magic := binary.LittleEndian.Uint32(trailer[0:4]); magic != skippableFrameMagic {
+		return fmt.Errorf("not a zstd-chunked archive: bad trailer magic %#x", magic)
+	}
+	tocOffset := binary.LittleEndian.Uint64(trailer[8:16])
+	tocLength := binary.LittleEndian.Uint64(trailer[16:24])
+
+	// checked separately (not tocOffset+tocLength > len) so a corrupt/truncated
+	// trailer can't overflow the sum past len(a.mm) and slip past this guard
+	size := uint64(len(a.mm))
+	if tocOffset > size || tocLength > size-tocOffset {
+		return fmt.Errorf("archive trailer points outside the file")
+	}
+
+	dec, err := zstd.NewReader(bytes.NewReader(a.mm[tocOffset : tocOffset+tocLength]))
+	if err != nil {
+		return fmt.Errorf("failed to open archive TOC frame: %v", err)
+	}
+	defer dec.Close()
+
+	data, err := io.ReadAll(dec)
+	if err != nil {
+		return fmt.Errorf("failed to read archive TOC: %v", err)
+	}
+
+	return json.Unmarshal(data, &a.toc)
+}
+
+// List returns every entry recorded in the archive's TOC.
+func (a *Archive) List() []TOCEntry {
+	return a.toc.Entries
+}
+
+// Get decompresses and returns the entry named path without touching any
+// other entry in the archive.
+func (a *Archive) Get(path string) (io.ReadCloser, error) {
+	for _, e := range a.toc.Entries {
+		if e.Path != path {
+			continue
+		}
+		dec, err := zstd.NewReader(bytes.NewReader(a.mm[e.Offset : e.Offset+e.Length]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open frame for %s: %v", path, err)
+		}
+		return dec.IOReadCloser(), nil
+	}
+	return nil, fmt.Errorf("no entry named %q in archive", path)
+}
+
+// Close unmaps and closes the underlying archive file.
+func (a *Archive) Close() error {
+	if err := a.mm.Unmap(); err != nil {
+		a.f.Close()
+		return err
+	}
+	return a.f.Close()
+}
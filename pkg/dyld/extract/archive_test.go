@@ -0,0 +1,111 @@
+/*
+Copyright © 2018-2023 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package extract
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchiveWriteReadRoundTrip(t *testing.T) {
+	entries := map[string][]byte{
+		"usr/lib/libfoo.dylib": bytes.Repeat([]byte("foo"), 1000),
+		"usr/lib/libbar.dylib": []byte("bar"),
+		"usr/lib/libbaz.dylib": {}, // empty file
+	}
+
+	path := filepath.Join(t.TempDir(), "test.ipswz")
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create archive file: %v", err)
+	}
+
+	aw := NewArchiveWriter(out)
+	for _, name := range []string{"usr/lib/libfoo.dylib", "usr/lib/libbar.dylib", "usr/lib/libbaz.dylib"} {
+		data := entries[name]
+		if err := aw.AddFile(name, bytes.NewReader(data), int64(len(data))); err != nil {
+			t.Fatalf("AddFile(%s) failed: %v", name, err)
+		}
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatalf("ArchiveWriter.Close failed: %v", err)
+	}
+	if err := out.Close(); err != nil {
+		t.Fatalf("failed to close archive file: %v", err)
+	}
+
+	archive, err := OpenArchive(path)
+	if err != nil {
+		t.Fatalf("OpenArchive failed: %v", err)
+	}
+	defer archive.Close()
+
+	toc := archive.List()
+	if len(toc) != len(entries) {
+		t.Fatalf("expected %d TOC entries, got %d", len(entries), len(toc))
+	}
+
+	for name, want := range entries {
+		r, err := archive.Get(name)
+		if err != nil {
+			t.Fatalf("Get(%s) failed: %v", name, err)
+		}
+		got, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: got %d bytes, want %d bytes", name, len(got), len(want))
+		}
+	}
+
+	if _, err := archive.Get("no/such/file"); err == nil {
+		t.Error("Get of a missing entry should fail")
+	}
+}
+
+// TestOpenArchiveRejectsOverflowingTrailer covers a corrupt/truncated archive
+// whose trailer's tocOffset+tocLength overflows uint64 - OpenArchive must
+// return an error rather than panicking with a slice-bounds-out-of-range.
+func TestOpenArchiveRejectsOverflowingTrailer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "corrupt.ipswz")
+
+	trailer := make([]byte, trailerSize)
+	binary.LittleEndian.PutUint32(trailer[0:4], skippableFrameMagic)
+	binary.LittleEndian.PutUint32(trailer[4:8], trailerContentSize)
+	binary.LittleEndian.PutUint64(trailer[8:16], 1)
+	binary.LittleEndian.PutUint64(trailer[16:24], math.MaxUint64) // overflows tocOffset+tocLength
+
+	if err := os.WriteFile(path, trailer, 0o640); err != nil {
+		t.Fatalf("failed to write corrupt archive: %v", err)
+	}
+
+	if _, err := OpenArchive(path); err == nil {
+		t.Error("OpenArchive should reject a trailer with an overflowing tocOffset+tocLength")
+	}
+}
@@ -0,0 +1,252 @@
+/*
+Copyright © 2018-2023 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package extract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"go.etcd.io/bbolt"
+)
+
+var indexBucket = []byte("extract")
+
+// Cache is a content-addressed cache for extracted dylibs: an index mapping
+// (DSC UUID, image UUID, slide, ipsw version) to the sha256 of the dylib that
+// extraction would have produced, plus a CAS object store holding the actual
+// bytes at <Dir>/<sha256[:2]>/<sha256>. It lets repeated extraction of the
+// same DSC (across tool iterations, or the same ipsw version twice) skip
+// re-running fixup parsing and slide rebasing in favor of a hardlink/copy out
+// of the CAS.
+//
+// This mirrors the shape of buildkit's contenthash cache: a small embedded KV
+// index (here bbolt, analogous to buildkit's use of an on-disk index) pointing
+// into an immutable, hash-addressed blob store.
+type Cache struct {
+	Dir string
+	db  *bbolt.DB
+}
+
+// DefaultCacheDir returns ~/.cache/ipsw/extracted, creating it does NOT
+// happen here - callers should still pass the result through OpenCache, which
+// creates it on demand.
+func DefaultCacheDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".cache", "ipsw", "extracted"), nil
+}
+
+// OpenCache opens (creating if necessary) the cache rooted at dir.
+func OpenCache(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create cache dir %s: %v", dir, err)
+	}
+
+	db, err := bbolt.Open(filepath.Join(dir, "index.db"), 0o640, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache index: %v", err)
+	}
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(indexBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize cache index: %v", err)
+	}
+
+	return &Cache{Dir: dir, db: db}, nil
+}
+
+// Close closes the cache's index.
+func (c *Cache) Close() error {
+	return c.db.Close()
+}
+
+// Key derives the cache key for extracting image imageUUID out of the DSC
+// identified by dscUUID, with the given slide setting, using this version of
+// ipsw (bumping the version invalidates the cache if extraction logic
+// changes between releases).
+func Key(dscUUID, imageUUID string, slide bool, ipswVersion string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%s/%v/%s", dscUUID, imageUUID, slide, ipswVersion)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Lookup returns the sha256 (hex) of the cached dylib for key, and ok=false
+// if there is no cache entry (or its blob has since been gc'd).
+func (c *Cache) Lookup(key string) (sha256hex string, ok bool, err error) {
+	err = c.db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket(indexBucket).Get([]byte(key))
+		if v != nil {
+			sha256hex = string(v)
+		}
+		return nil
+	})
+	if err != nil || sha256hex == "" {
+		return "", false, err
+	}
+	if _, statErr := os.Stat(c.objectPath(sha256hex)); os.IsNotExist(statErr) {
+		return "", false, nil
+	}
+	return sha256hex, true, nil
+}
+
+// objectPath returns the CAS path for a blob with the given sha256 hex digest.
+func (c *Cache) objectPath(sha256hex string) string {
+	return filepath.Join(c.Dir, sha256hex[:2], sha256hex)
+}
+
+// Store copies path into the CAS, recording it under key, and returns the
+// sha256 (hex) it was stored as.
+func (c *Cache) Store(key, path string) (string, error) {
+	sum, err := sha256File(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash %s: %v", path, err)
+	}
+
+	dest := c.objectPath(sum)
+	if _, err := os.Stat(dest); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(dest), 0o750); err != nil {
+			return "", err
+		}
+		if err := copyFile(path, dest); err != nil {
+			return "", fmt.Errorf("failed to store %s in cache: %v", path, err)
+		}
+	}
+
+	if err := c.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(indexBucket).Put([]byte(key), []byte(sum))
+	}); err != nil {
+		return "", fmt.Errorf("failed to record cache entry: %v", err)
+	}
+
+	return sum, nil
+}
+
+// Link materializes the cached blob sha256hex at dest, hardlinking when
+// possible (the CAS is immutable, so this is safe) and falling back to a copy
+// across filesystem boundaries.
+func (c *Cache) Link(sha256hex, dest string) error {
+	src := c.objectPath(sha256hex)
+	if err := os.MkdirAll(filepath.Dir(dest), 0o750); err != nil {
+		return err
+	}
+	os.Remove(dest) // Link fails if dest already exists
+	if err := os.Link(src, dest); err != nil {
+		return copyFile(src, dest)
+	}
+	return nil
+}
+
+// GC removes CAS blobs no index entry references, and index entries whose
+// blob is missing. It returns the number of orphaned blobs removed.
+func (c *Cache) GC() (int, error) {
+	live := make(map[string]bool)
+	if err := c.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(indexBucket).ForEach(func(_, v []byte) error {
+			live[string(v)] = true
+			return nil
+		})
+	}); err != nil {
+		return 0, err
+	}
+
+	indexPath := filepath.Join(c.Dir, "index.db")
+
+	removed := 0
+	err := filepath.Walk(c.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || path == indexPath {
+			return nil
+		}
+		sum := filepath.Base(path)
+		if live[sum] {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed++
+		return nil
+	})
+	if err != nil {
+		return removed, err
+	}
+
+	if err := c.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(indexBucket)
+		var stale [][]byte
+		if err := b.ForEach(func(k, v []byte) error {
+			if _, statErr := os.Stat(c.objectPath(string(v))); os.IsNotExist(statErr) {
+				stale = append(stale, append([]byte(nil), k...))
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, k := range stale {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return removed, err
+	}
+
+	return removed, nil
+}
+
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return err
+}
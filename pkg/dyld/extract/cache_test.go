@@ -0,0 +1,171 @@
+/*
+Copyright © 2018-2023 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package extract
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.etcd.io/bbolt"
+)
+
+// deleteIndexEntry removes key's index entry directly, simulating a blob that
+// has become unreferenced (e.g. superseded by a newer Store under the same
+// logical image, or left behind by a bumped ipsw version) without going
+// through any exported Cache API.
+func deleteIndexEntry(t *testing.T, c *Cache, key string) {
+	t.Helper()
+	if err := c.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(indexBucket).Delete([]byte(key))
+	}); err != nil {
+		t.Fatalf("failed to delete index entry for %s: %v", key, err)
+	}
+}
+
+func writeTempFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o640); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	return path
+}
+
+func TestCacheStoreLookupLink(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := OpenCache(filepath.Join(dir, "cache"))
+	if err != nil {
+		t.Fatalf("OpenCache failed: %v", err)
+	}
+	defer cache.Close()
+
+	key := Key("dsc-uuid", "image-uuid", false, "v1.0.0")
+
+	if _, ok, err := cache.Lookup(key); err != nil || ok {
+		t.Fatalf("Lookup on empty cache: ok=%v err=%v, want ok=false", ok, err)
+	}
+
+	src := writeTempFile(t, dir, "libfoo.dylib", []byte("dylib contents"))
+	sum, err := cache.Store(key, src)
+	if err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	gotSum, ok, err := cache.Lookup(key)
+	if err != nil || !ok {
+		t.Fatalf("Lookup after Store: ok=%v err=%v, want ok=true", ok, err)
+	}
+	if gotSum != sum {
+		t.Errorf("Lookup sha256 = %s, want %s", gotSum, sum)
+	}
+
+	dest := filepath.Join(dir, "linked.dylib")
+	if err := cache.Link(sum, dest); err != nil {
+		t.Fatalf("Link failed: %v", err)
+	}
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("failed to read linked file: %v", err)
+	}
+	if string(got) != "dylib contents" {
+		t.Errorf("linked file contents = %q, want %q", got, "dylib contents")
+	}
+}
+
+func TestCacheGCReclaimsOrphans(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := OpenCache(filepath.Join(dir, "cache"))
+	if err != nil {
+		t.Fatalf("OpenCache failed: %v", err)
+	}
+	defer cache.Close()
+
+	liveKey := Key("dsc-uuid", "live-image", false, "v1.0.0")
+	liveSrc := writeTempFile(t, dir, "live.dylib", []byte("live"))
+	liveSum, err := cache.Store(liveKey, liveSrc)
+	if err != nil {
+		t.Fatalf("Store(live) failed: %v", err)
+	}
+
+	orphanKey := Key("dsc-uuid", "orphan-image", false, "v1.0.0")
+	orphanSrc := writeTempFile(t, dir, "orphan.dylib", []byte("orphan"))
+	orphanSum, err := cache.Store(orphanKey, orphanSrc)
+	if err != nil {
+		t.Fatalf("Store(orphan) failed: %v", err)
+	}
+
+	// drop the orphan's index entry directly so its blob becomes unreferenced,
+	// simulating a stale entry left behind by e.g. a bumped ipsw version.
+	deleteIndexEntry(t, cache, orphanKey)
+
+	removed, err := cache.GC()
+	if err != nil {
+		t.Fatalf("GC failed: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("GC removed %d blob(s), want 1", removed)
+	}
+
+	if _, err := os.Stat(cache.objectPath(orphanSum)); !os.IsNotExist(err) {
+		t.Error("orphaned blob still exists after GC")
+	}
+	if _, err := os.Stat(cache.objectPath(liveSum)); err != nil {
+		t.Errorf("live blob was removed by GC: %v", err)
+	}
+	if _, ok, err := cache.Lookup(liveKey); err != nil || !ok {
+		t.Errorf("live cache entry lost after GC: ok=%v err=%v", ok, err)
+	}
+	if _, err := os.Stat(filepath.Join(cache.Dir, "index.db")); err != nil {
+		t.Errorf("GC removed the index itself: %v", err)
+	}
+}
+
+func TestCacheGCPrunesStaleIndexEntries(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := OpenCache(filepath.Join(dir, "cache"))
+	if err != nil {
+		t.Fatalf("OpenCache failed: %v", err)
+	}
+	defer cache.Close()
+
+	key := Key("dsc-uuid", "missing-blob-image", false, "v1.0.0")
+	src := writeTempFile(t, dir, "gone.dylib", []byte("gone"))
+	sum, err := cache.Store(key, src)
+	if err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+
+	// simulate the blob having been removed out from under the index, e.g. by
+	// a previous GC run that cleaned the CAS but died before this index pass.
+	if err := os.Remove(cache.objectPath(sum)); err != nil {
+		t.Fatalf("failed to remove blob: %v", err)
+	}
+
+	if _, err := cache.GC(); err != nil {
+		t.Fatalf("GC failed: %v", err)
+	}
+
+	if _, ok, err := cache.Lookup(key); err != nil || ok {
+		t.Errorf("Lookup after GC: ok=%v err=%v, want ok=false (stale index entry should be pruned)", ok, err)
+	}
+}
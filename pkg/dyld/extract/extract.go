@@ -0,0 +1,152 @@
+/*
+Copyright © 2018-2023 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+// Package extract splits dyld_shared_cache dylib extraction into composable
+// Extractor/Finder/Uploader pieces so callers (the `ipsw dyld extract` command,
+// but also anything else that wants to pull dylibs out of a DSC) aren't forced
+// to reimplement the find-then-export-then-rebase-then-ship pipeline by hand.
+package extract
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/blacktop/go-macho"
+	"github.com/blacktop/go-macho/pkg/fixupchains"
+	"github.com/blacktop/ipsw/pkg/dyld"
+)
+
+// Extractor exports dylibs out of a dyld_shared_cache, optionally applying the
+// cache's slide info so the resulting Mach-O is rebased as if it were loaded at
+// its on-disk preferred address.
+//
+// NOTE: an Extractor is NOT safe for concurrent use. GetMappingForVMAddress
+// and GetRebaseInfoForPages both seek and read through the single
+// io.ReaderAt that dyld.Open hands back, so callers that want to extract in
+// parallel must give each goroutine its own Extractor wrapping its own
+// *dyld.File opened from the same path.
+type Extractor struct {
+	dsc   *dyld.File
+	Slide bool
+}
+
+// NewExtractor returns an Extractor that reads dylibs out of dsc.
+func NewExtractor(dsc *dyld.File, slide bool) *Extractor {
+	return &Extractor{dsc: dsc, Slide: slide}
+}
+
+// Extract exports image to dest, slide-rebasing it first if e.Slide is set.
+// It returns dest on success.
+func (e *Extractor) Extract(image *dyld.CacheImage, dest string) (string, error) {
+	m, err := image.GetMacho()
+	if err != nil {
+		return "", err
+	}
+	defer m.Close()
+
+	return e.ExtractMacho(m, image, dest)
+}
+
+// ExtractMacho is Extract, but against a Mach-O the caller has already opened
+// via image.GetMacho() - for callers (like extractOne's cache-key lookup)
+// that need to read the Mach-O before deciding whether to extract at all, so
+// they don't pay for parsing it twice.
+func (e *Extractor) ExtractMacho(m *macho.File, image *dyld.CacheImage, dest string) (string, error) {
+	var dcf *fixupchains.DyldChainedFixups
+	var err error
+	if m.HasFixups() {
+		dcf, err = m.DyldChainedFixups()
+		if err != nil {
+			return "", fmt.Errorf("failed to parse fixups from in memory MachO: %v", err)
+		}
+	}
+
+	image.ParseLocalSymbols(false)
+
+	if err := m.Export(dest, dcf, m.GetBaseAddress(), image.GetLocalSymbolsAsMachoSymbols()); err != nil {
+		return "", fmt.Errorf("failed to extract dylib %s: %v", image.Name, err)
+	}
+
+	if e.Slide {
+		if err := rebaseMachO(e.dsc, dest); err != nil {
+			os.Remove(dest) // don't leave a half-rebased dylib behind
+			return "", fmt.Errorf("failed to rebase dylib via cache slide info: %v", err)
+		}
+	}
+
+	return dest, nil
+}
+
+// rebaseMachO walks machoPath's segments and overwrites every slid pointer with
+// the value dsc's slide info says it should resolve to, turning a freshly
+// exported dylib into one that looks like it was loaded at its real address.
+func rebaseMachO(dsc *dyld.File, machoPath string) error {
+	f, err := os.OpenFile(machoPath, os.O_RDWR, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to open exported MachO %s: %v", machoPath, err)
+	}
+	defer f.Close()
+
+	mm, err := macho.NewFile(f)
+	if err != nil {
+		return err
+	}
+
+	for _, seg := range mm.Segments() {
+		uuid, mapping, err := dsc.GetMappingForVMAddress(seg.Addr)
+		if err != nil {
+			return err
+		}
+
+		if mapping.SlideInfoOffset == 0 {
+			continue
+		}
+
+		startAddr := seg.Addr - mapping.Address
+		endAddr := ((seg.Addr + seg.Memsz) - mapping.Address) + uint64(dsc.SlideInfo.GetPageSize())
+
+		start := startAddr / uint64(dsc.SlideInfo.GetPageSize())
+		end := endAddr / uint64(dsc.SlideInfo.GetPageSize())
+
+		rebases, err := dsc.GetRebaseInfoForPages(uuid, mapping, start, end)
+		if err != nil {
+			return err
+		}
+
+		for _, rebase := range rebases {
+			off, err := mm.GetOffset(rebase.CacheVMAddress)
+			if err != nil {
+				continue
+			}
+			if _, err := f.Seek(int64(off), io.SeekStart); err != nil {
+				return fmt.Errorf("failed to seek in exported file to offset %#x from the start: %v", off, err)
+			}
+			if err := binary.Write(f, dsc.ByteOrder, rebase.Target); err != nil {
+				return fmt.Errorf("failed to write rebase address %#x: %v", rebase.Target, err)
+			}
+		}
+	}
+
+	return nil
+}
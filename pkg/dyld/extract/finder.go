@@ -0,0 +1,103 @@
+/*
+Copyright © 2018-2023 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package extract
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/blacktop/ipsw/pkg/dyld"
+)
+
+// Finder resolves dylibs inside a dyld_shared_cache by install name, glob,
+// regex or Mach-O LC_UUID, so callers don't have to walk dsc.Images by hand.
+type Finder struct {
+	dsc *dyld.File
+}
+
+// NewFinder returns a Finder that resolves dylibs against dsc.
+func NewFinder(dsc *dyld.File) *Finder {
+	return &Finder{dsc: dsc}
+}
+
+// Find resolves pattern against every image's install name (dsc.Images[i].Name).
+// It tries, in order: an exact install-name match, a filepath.Match glob, and
+// finally a regexp match - returning every image a matching strategy finds.
+func (s *Finder) Find(pattern string) ([]*dyld.CacheImage, error) {
+	if image, err := s.dsc.Image(pattern); err == nil {
+		return []*dyld.CacheImage{image}, nil
+	}
+
+	var matches []*dyld.CacheImage
+
+	if ok, _ := filepath.Match(pattern, pattern); ok && strings.ContainsAny(pattern, "*?[") {
+		for _, image := range s.dsc.Images {
+			if matched, _ := filepath.Match(pattern, image.Name); matched {
+				matches = append(matches, image)
+			}
+		}
+		if len(matches) > 0 {
+			return matches, nil
+		}
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find dylib matching %q: not an install name, glob or valid regexp: %v", pattern, err)
+	}
+	for _, image := range s.dsc.Images {
+		if re.MatchString(image.Name) {
+			matches = append(matches, image)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no dylib found matching %q", pattern)
+	}
+
+	return matches, nil
+}
+
+// FindByUUID returns the image whose Mach-O LC_UUID matches uuid (case
+// insensitive, dashes optional).
+func (s *Finder) FindByUUID(uuid string) (*dyld.CacheImage, error) {
+	want := normalizeUUID(uuid)
+
+	for _, image := range s.dsc.Images {
+		m, err := image.GetMacho()
+		if err != nil {
+			continue
+		}
+		got := normalizeUUID(m.UUID().String())
+		m.Close()
+		if got == want {
+			return image, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no dylib found with UUID %s", uuid)
+}
+
+func normalizeUUID(uuid string) string {
+	return strings.ToLower(strings.ReplaceAll(uuid, "-", ""))
+}
@@ -0,0 +1,70 @@
+/*
+Copyright © 2018-2023 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package extract
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+)
+
+// ArchiveEntryWriter is the common shape of ArchiveWriter (zstd-chunked) and
+// TarWriter (plain tar): stream a named, sized entry in, and finalize once
+// done. extractAllToArchive in the `ipsw dyld extract` command is written
+// against this so it doesn't care which format it's feeding.
+type ArchiveEntryWriter interface {
+	AddFile(path string, r io.Reader, size int64) error
+	Close() error
+}
+
+// TarWriter is a plain, streaming tar ArchiveEntryWriter - no compression, no
+// TOC, no random access - for piping extraction straight into another tool
+// (`ipsw dyld extract --all --archive tar DSC - | tar -x`).
+type TarWriter struct {
+	tw *tar.Writer
+}
+
+// NewTarWriter returns a TarWriter that streams entries into w.
+func NewTarWriter(w io.Writer) *TarWriter {
+	return &TarWriter{tw: tar.NewWriter(w)}
+}
+
+// AddFile writes path as a regular-file tar entry containing r's contents.
+func (t *TarWriter) AddFile(path string, r io.Reader, size int64) error {
+	if err := t.tw.WriteHeader(&tar.Header{
+		Name: path,
+		Mode: 0o644,
+		Size: size,
+	}); err != nil {
+		return fmt.Errorf("failed to write tar header for %s: %v", path, err)
+	}
+	if _, err := io.Copy(t.tw, r); err != nil {
+		return fmt.Errorf("failed to write tar entry for %s: %v", path, err)
+	}
+	return nil
+}
+
+// Close flushes the tar footer. The underlying io.Writer is left open since
+// TarWriter doesn't own it (it may be os.Stdout).
+func (t *TarWriter) Close() error {
+	return t.tw.Close()
+}
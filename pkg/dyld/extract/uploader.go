@@ -0,0 +1,195 @@
+/*
+Copyright © 2018-2023 blacktop
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package extract
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// Sink is a pluggable destination a Mach-O (or its companion .dSYM) can be
+// uploaded to, keyed by the binary's Mach-O UUID. LocalSink and HTTPSink cover
+// "local dir" and "company symbol server over HTTP" respectively; a gRPC or S3
+// sink can be added later by implementing the same interface.
+type Sink interface {
+	// Exists reports whether name is already stored for uuid, so callers can
+	// skip re-uploading unchanged binaries.
+	Exists(ctx context.Context, uuid, name string) (bool, error)
+	// Upload streams src, named name, into the sink under uuid.
+	Upload(ctx context.Context, uuid, name string, src io.Reader) error
+}
+
+// Uploader pushes extracted dylibs (and their companion .dSYM, if any) into a
+// Sink, keyed by Mach-O UUID so a symbol server can dedupe across ipsw
+// versions that happen to share a dylib.
+type Uploader struct {
+	Sink Sink
+	// OnlyMissing skips uploading a file the Sink already has a blob for.
+	OnlyMissing bool
+}
+
+// NewUploader returns an Uploader that pushes into sink.
+func NewUploader(sink Sink, onlyMissing bool) *Uploader {
+	return &Uploader{Sink: sink, OnlyMissing: onlyMissing}
+}
+
+// Upload uploads every path in paths (e.g. the extracted dylib and its
+// .dSYM companion) under uuid, skipping any the Sink already has when
+// u.OnlyMissing is set.
+func (u *Uploader) Upload(ctx context.Context, uuid string, paths ...string) error {
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		name := filepath.Base(path)
+
+		if u.OnlyMissing {
+			exists, err := u.Sink.Exists(ctx, uuid, name)
+			if err != nil {
+				return fmt.Errorf("failed to check if %s already exists for %s: %v", name, uuid, err)
+			}
+			if exists {
+				continue
+			}
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open %s for upload: %v", path, err)
+		}
+		err = u.Sink.Upload(ctx, uuid, name, f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to upload %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// LocalSink stores blobs under Dir/<uuid>/<name>, useful for staging uploads
+// or pointing the uploader at another tool's watch folder.
+type LocalSink struct {
+	Dir string
+}
+
+// NewLocalSink returns a Sink that writes into dir.
+func NewLocalSink(dir string) *LocalSink {
+	return &LocalSink{Dir: dir}
+}
+
+func (s *LocalSink) path(uuid, name string) string {
+	return filepath.Join(s.Dir, uuid, name)
+}
+
+func (s *LocalSink) Exists(_ context.Context, uuid, name string) (bool, error) {
+	_, err := os.Stat(s.path(uuid, name))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *LocalSink) Upload(_ context.Context, uuid, name string, src io.Reader) error {
+	dest := s.path(uuid, name)
+	if err := os.MkdirAll(filepath.Dir(dest), 0o750); err != nil {
+		return err
+	}
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, src)
+	return err
+}
+
+// HTTPSink uploads to a symbol server reachable over HTTP, authenticating
+// with a bearer token (the `--upload-url`/`--bearer-token` flags on
+// `ipsw dyld extract`). Blobs are addressed as "<BaseURL>/<uuid>/<name>".
+type HTTPSink struct {
+	BaseURL     string
+	BearerToken string
+	Client      *http.Client
+}
+
+// NewHTTPSink returns a Sink that PUTs blobs to baseURL, sending token as a
+// bearer credential when non-empty.
+func NewHTTPSink(baseURL, token string) *HTTPSink {
+	return &HTTPSink{BaseURL: baseURL, BearerToken: token, Client: http.DefaultClient}
+}
+
+func (s *HTTPSink) url(uuid, name string) string {
+	return fmt.Sprintf("%s/%s/%s", s.BaseURL, uuid, name)
+}
+
+func (s *HTTPSink) authorize(req *http.Request) {
+	if s.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.BearerToken)
+	}
+}
+
+func (s *HTTPSink) Exists(ctx context.Context, uuid, name string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.url(uuid, name), nil)
+	if err != nil {
+		return false, err
+	}
+	s.authorize(req)
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected status %s checking for %s/%s", resp.Status, uuid, name)
+	}
+}
+
+func (s *HTTPSink) Upload(ctx context.Context, uuid, name string, src io.Reader) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.url(uuid, name), src)
+	if err != nil {
+		return err
+	}
+	s.authorize(req)
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status %s uploading %s/%s", resp.Status, uuid, name)
+	}
+	return nil
+}